@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
@@ -14,6 +15,21 @@ import (
 	"github.com/valyala/fasthttp"
 )
 
+// Atreugo is the server, it wraps a fasthttp.Server and a Router
+type Atreugo struct {
+	*Router
+
+	server *fasthttp.Server
+	cfg    *Config
+	log    *logger.Logger
+	lnAddr string
+
+	// acmeChallengeServer is the net/http server answering ACME HTTP-01
+	// challenges on port 80 when Config.AutoTLSHosts is set. It's nil
+	// otherwise.
+	acmeChallengeServer *http.Server
+}
+
 // New create a new instance of Atreugo Server
 func New(cfg *Config) *Atreugo {
 	if cfg.Name == "" {
@@ -36,6 +52,8 @@ func New(cfg *Config) *Atreugo {
 	log := logger.New(cfg.LogName, cfg.LogLevel, os.Stderr)
 
 	r := newRouter(log)
+	r.scheduler = cfg.Scheduler
+
 	if cfg.NotFoundView != nil {
 		r.router.NotFound = viewToHandler(cfg.NotFoundView)
 	}
@@ -81,6 +99,7 @@ func New(cfg *Config) *Atreugo {
 			NoDefaultContentType:               cfg.NoDefaultContentType,
 			ConnState:                          cfg.ConnState,
 			KeepHijackedConns:                  cfg.KeepHijackedConns,
+			StreamRequestBody:                  cfg.StreamRequestBody,
 			Logger:                             log,
 		},
 
@@ -90,6 +109,8 @@ func New(cfg *Config) *Atreugo {
 		Router: r,
 	}
 
+	r.server = server.server
+
 	return server
 }
 
@@ -101,7 +122,7 @@ func New(cfg *Config) *Atreugo {
 // with the Listener address automatically
 func (s *Atreugo) Serve(ln net.Listener) error {
 	schema := "http"
-	if s.cfg.TLSEnable {
+	if s.cfg.TLSEnable || len(s.cfg.AutoTLSHosts) > 0 {
 		schema = "https"
 	}
 
@@ -119,8 +140,13 @@ func (s *Atreugo) Serve(ln net.Listener) error {
 	}
 
 	s.log.Infof("Listening on: %s://%s/", schema, s.lnAddr)
+
+	if len(s.cfg.AutoTLSHosts) > 0 {
+		return s.serveAutoTLS(ln)
+	}
+
 	if s.cfg.TLSEnable {
-		return s.server.ServeTLS(ln, s.cfg.CertFile, s.cfg.CertKey)
+		return s.serveManualTLSWithReload(ln)
 	}
 
 	return s.server.Serve(ln)
@@ -163,6 +189,14 @@ func (s *Atreugo) ServeGracefully(ln net.Listener) error {
 			return err
 		}
 
+		if s.acmeChallengeServer != nil {
+			if err := s.acmeChallengeServer.Close(); err != nil {
+				s.log.Error(err)
+			}
+		}
+
+		s.Router.closers.closeAll()
+
 		s.log.Infof("Server gracefully stopped")
 	}
 
@@ -174,6 +208,11 @@ func (s *Atreugo) SetLogOutput(output io.Writer) {
 	s.log.SetOutput(output)
 }
 
+// getListener returns a listener for the host/port configured in the server
+func (s *Atreugo) getListener() (net.Listener, error) {
+	return net.Listen(s.cfg.Network, s.lnAddr)
+}
+
 // ListenAndServe serves HTTP/HTTPS requests from the given TCP4 addr in the atreugo configuration.
 //
 // Pass custom listener to Serve/ServeGracefully if you need listening on non-TCP4 media
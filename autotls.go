@@ -0,0 +1,147 @@
+package atreugo
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const defaultAutoTLSCacheDir = "./certs"
+
+// serveAutoTLS serves ln using certificates automatically provisioned
+// through ACME/Let's Encrypt for s.cfg.AutoTLSHosts, starting the HTTP-01
+// challenge handler on port 80.
+func (s *Atreugo) serveAutoTLS(ln net.Listener) error {
+	cacheDir := s.cfg.AutoTLSCacheDir
+	if cacheDir == "" {
+		cacheDir = defaultAutoTLSCacheDir
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(s.cfg.AutoTLSHosts...),
+		Cache:      autocert.DirCache(cacheDir),
+	}
+
+	challengeLn, err := net.Listen("tcp", ":80")
+	if err != nil {
+		return fmt.Errorf("atreugo: failed to start ACME HTTP-01 challenge listener on :80: %w", err)
+	}
+
+	s.acmeChallengeServer = &http.Server{Handler: m.HTTPHandler(nil)}
+
+	go func() {
+		if err := s.acmeChallengeServer.Serve(challengeLn); err != nil && err != http.ErrServerClosed {
+			s.log.Error(err)
+		}
+	}()
+	defer s.acmeChallengeServer.Close()
+
+	tlsConfig := m.TLSConfig()
+
+	// fasthttp only speaks HTTP/1.x, so don't offer "h2" over ALPN.
+	protos := tlsConfig.NextProtos[:0]
+	for _, p := range tlsConfig.NextProtos {
+		if p != "h2" {
+			protos = append(protos, p)
+		}
+	}
+	tlsConfig.NextProtos = protos
+
+	if s.cfg.OnCertLoaded != nil {
+		getCert := tlsConfig.GetCertificate
+		tlsConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := getCert(hello)
+			if err == nil {
+				s.cfg.OnCertLoaded(hello.ServerName)
+			}
+
+			return cert, err
+		}
+	}
+
+	return s.server.Serve(tls.NewListener(ln, tlsConfig))
+}
+
+// manualCertReloader serves a static CertFile/CertKey pair, reloading them
+// from disk on demand so a long-running server can rotate certs without a
+// restart.
+type manualCertReloader struct {
+	certFile, keyFile string
+	onCertLoaded      func(hostname string)
+
+	cert atomic.Value // *tls.Certificate
+}
+
+func newManualCertReloader(certFile, keyFile string, onCertLoaded func(hostname string)) (*manualCertReloader, error) {
+	r := &manualCertReloader{certFile: certFile, keyFile: keyFile, onCertLoaded: onCertLoaded}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *manualCertReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.cert.Store(&cert)
+
+	if r.onCertLoaded != nil {
+		r.onCertLoaded(r.certFile)
+	}
+
+	return nil
+}
+
+func (r *manualCertReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load().(*tls.Certificate), nil
+}
+
+// watchSIGHUP reloads the certificate every time the process receives
+// SIGHUP, logging (but not propagating) reload errors so a bad cert on disk
+// doesn't bring down an otherwise healthy server, until stop is closed.
+func (r *manualCertReloader) watchSIGHUP(log interface{ Error(...interface{}) }, stop <-chan struct{}) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-sighup:
+			if err := r.reload(); err != nil {
+				log.Error(err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// serveManualTLSWithReload serves ln using s.cfg.CertFile/CertKey, watching
+// SIGHUP to reload the certificate pair from disk without restarting.
+func (s *Atreugo) serveManualTLSWithReload(ln net.Listener) error {
+	reloader, err := newManualCertReloader(s.cfg.CertFile, s.cfg.CertKey, s.cfg.OnCertLoaded)
+	if err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	go reloader.watchSIGHUP(s.log, stop)
+	s.Router.closers.add(func() { close(stop) })
+
+	tlsConfig := &tls.Config{GetCertificate: reloader.getCertificate}
+
+	return s.server.Serve(tls.NewListener(ln, tlsConfig))
+}
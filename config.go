@@ -0,0 +1,98 @@
+package atreugo
+
+import (
+	"net"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Config config to run server
+type Config struct {
+	// Name of server, sent in the 'Server' response header
+	Name string
+
+	// Host to listen on
+	Host string
+	// Port to listen on
+	Port int
+
+	// Network is the network to listen on, default is "tcp"
+	Network string
+
+	// LogLevel is the log level used by the default logger, default is "info"
+	LogLevel string
+	// LogName is the name used by the default logger, default is "atreugo"
+	LogName string
+
+	// GracefulShutdown enables the graceful server shutdown
+	GracefulShutdown bool
+
+	// TLSEnable enables TLS in the server using CertFile/CertKey. Either way,
+	// connections are served as HTTP/1.x only: fasthttp has no HTTP/2
+	// support, so TLS never advertises "h2" over ALPN.
+	TLSEnable bool
+	CertFile  string
+	CertKey   string
+
+	// AutoTLSHosts, when non-empty, enables automatic certificate
+	// provisioning through Let's Encrypt (via golang.org/x/crypto/acme/autocert)
+	// for the given hostnames, taking precedence over CertFile/CertKey. The
+	// ACME HTTP-01 challenge handler is served on port 80 automatically.
+	AutoTLSHosts []string
+	// AutoTLSCacheDir is where AutoTLSHosts certificates are cached between
+	// restarts. Defaults to "./certs".
+	AutoTLSCacheDir string
+
+	// OnCertLoaded, if set, is called when a TLS certificate is obtained.
+	// For AutoTLSHosts it's called on every TLS handshake that gets a
+	// certificate, with the negotiated hostname (SNI); it doesn't tell a
+	// freshly issued certificate apart from a cached one. For the manual
+	// CertFile/CertKey path it's called on the initial load and on every
+	// SIGHUP reload, and receives CertFile since there's no per-connection
+	// hostname.
+	OnCertLoaded func(hostname string)
+
+	// Compress enables transparent response compression
+	Compress bool
+
+	// StreamRequestBody enables request body streaming on the underlying
+	// fasthttp.Server, letting handlers read the body as it arrives instead
+	// of waiting for it to be fully buffered. It's turned on automatically
+	// when a route is registered with Router.StreamPath, but can also be
+	// set explicitly here.
+	StreamRequestBody bool
+
+	// NotFoundView is called when no matching route is found
+	NotFoundView View
+	// MethodNotAllowedView is called when a route matches the path but not the method
+	MethodNotAllowedView View
+	// PanicView is called when a panic happens inside a view or middleware
+	PanicView func(ctx *RequestCtx, err interface{})
+
+	// Scheduler is the default admission-control policy applied to every
+	// route, unless overridden per-route with Filters.Scheduler. Leave nil
+	// to disable admission control.
+	Scheduler Scheduler
+
+	Concurrency                        int
+	DisableKeepalive                    bool
+	ReadBufferSize                      int
+	WriteBufferSize                     int
+	ReadTimeout                         time.Duration
+	WriteTimeout                        time.Duration
+	IdleTimeout                         time.Duration
+	MaxConnsPerIP                       int
+	MaxRequestsPerConn                  int
+	MaxKeepaliveDuration                time.Duration
+	MaxRequestBodySize                  int
+	ReduceMemoryUsage                   bool
+	GetOnly                             bool
+	LogAllErrors                        bool
+	DisableHeaderNamesNormalizing       bool
+	SleepWhenConcurrencyLimitsExceeded  time.Duration
+	NoDefaultServerHeader               bool
+	NoDefaultContentType                bool
+	ConnState                           func(conn net.Conn, state fasthttp.ConnState)
+	KeepHijackedConns                   bool
+}
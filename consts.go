@@ -0,0 +1,10 @@
+package atreugo
+
+import "time"
+
+const (
+	defaultServerName = "Atreugo"
+	defaultNetwork     = "tcp"
+	defaultReadTimeout = 3 * time.Second
+	defaultLogName     = "atreugo"
+)
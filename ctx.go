@@ -0,0 +1,63 @@
+package atreugo
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+)
+
+// JSON is a shortcut for building JSON responses
+type JSON map[string]interface{}
+
+// RequestCtx wraps fasthttp.RequestCtx, adding some convenient helpers
+type RequestCtx struct {
+	*fasthttp.RequestCtx
+}
+
+var ctxPool = sync.Pool{
+	New: func() interface{} {
+		return new(RequestCtx)
+	},
+}
+
+func acquireRequestCtx(ctx *fasthttp.RequestCtx) *RequestCtx {
+	actx := ctxPool.Get().(*RequestCtx)
+	actx.RequestCtx = ctx
+
+	return actx
+}
+
+func releaseRequestCtx(actx *RequestCtx) {
+	actx.RequestCtx = nil
+	ctxPool.Put(actx)
+}
+
+// HTTPResponse responses with a html body
+func (ctx *RequestCtx) HTTPResponse(body string) error {
+	ctx.SetContentType("text/html; charset=utf-8")
+	ctx.SetBodyString(body)
+
+	return nil
+}
+
+// TextResponse responses with a plain text body
+func (ctx *RequestCtx) TextResponse(body string) error {
+	ctx.SetContentType("text/plain; charset=utf-8")
+	ctx.SetBodyString(body)
+
+	return nil
+}
+
+// JSONResponse responses marshaling the given struct to json
+func (ctx *RequestCtx) JSONResponse(response JSON) error {
+	b, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	ctx.SetContentType("application/json")
+	ctx.SetBody(b)
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package atreugo
+
+// Filters are the middlewares to execute before and after a view
+type Filters struct {
+	Before []Middleware
+	After  []Middleware
+
+	// Scheduler overrides the router's default Config.Scheduler for this
+	// route only. Leave nil to use the router's default (which may also be
+	// nil, meaning no admission control).
+	Scheduler Scheduler
+}
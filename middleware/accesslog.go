@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	atreugo "github.com/savsgio/atreugo/v8"
+	logger "github.com/savsgio/go-logger"
+)
+
+const (
+	requestIDHeader = "X-Request-Id"
+)
+
+// AccessLogRecord is the information gathered for a single request by the
+// access-log middleware and handed to a Formatter.
+type AccessLogRecord struct {
+	Time      time.Time
+	Method    string
+	Path      string
+	Status    int
+	Bytes     int
+	Latency   time.Duration
+	RemoteIP  string
+	UserAgent string
+	Referrer  string
+	RequestID string
+	Fields    map[string]interface{}
+}
+
+// Formatter renders an AccessLogRecord to a single log line.
+type Formatter func(rec AccessLogRecord) string
+
+// ApacheCombinedFormatter renders records using the Apache "combined" log
+// format.
+func ApacheCombinedFormatter(rec AccessLogRecord) string {
+	return fmt.Sprintf(`%s - - [%s] "%s %s" %d %d "%s" "%s"`,
+		rec.RemoteIP, rec.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		rec.Method, rec.Path, rec.Status, rec.Bytes, rec.Referrer, rec.UserAgent)
+}
+
+// JSONFormatter renders records as a single-line JSON object.
+func JSONFormatter(rec AccessLogRecord) string {
+	fields := make(map[string]interface{}, len(rec.Fields)+8)
+	for k, v := range rec.Fields {
+		fields[k] = v
+	}
+
+	fields["time"] = rec.Time.Format(time.RFC3339)
+	fields["method"] = rec.Method
+	fields["path"] = rec.Path
+	fields["status"] = rec.Status
+	fields["bytes"] = rec.Bytes
+	fields["latency_ms"] = float64(rec.Latency) / float64(time.Millisecond)
+	fields["remote_ip"] = rec.RemoteIP
+	fields["user_agent"] = rec.UserAgent
+	fields["referrer"] = rec.Referrer
+	fields["request_id"] = rec.RequestID
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Sprintf(`{"error":"accesslog: %s"}`, err)
+	}
+
+	return string(b)
+}
+
+// AccessLogConfig configures NewAccessLog.
+type AccessLogConfig struct {
+	// Logger receives the rendered log line. Defaults to a new
+	// logger.Logger writing to stderr.
+	Logger *logger.Logger
+
+	// Formatter renders each AccessLogRecord. Defaults to JSONFormatter.
+	Formatter Formatter
+
+	// SampleRate logs 1 in N requests when > 1. Defaults to 1 (log every
+	// request).
+	SampleRate int
+	// SampleStatusClasses, if non-empty, are always logged regardless of
+	// SampleRate (e.g. []int{4, 5} to always log 4xx/5xx responses).
+	SampleStatusClasses []int
+
+	// SlowThreshold, if > 0, escalates the log level to Warning for
+	// requests whose latency meets or exceeds it.
+	SlowThreshold time.Duration
+}
+
+func (cfg *AccessLogConfig) setDefaults() {
+	if cfg.Logger == nil {
+		cfg.Logger = logger.New("atreugo-accesslog", logger.INFO, os.Stderr)
+	}
+	if cfg.Formatter == nil {
+		cfg.Formatter = JSONFormatter
+	}
+	if cfg.SampleRate <= 0 {
+		cfg.SampleRate = 1
+	}
+}
+
+func (cfg *AccessLogConfig) shouldSample(n uint64, status int) bool {
+	if cfg.SampleRate <= 1 {
+		return true
+	}
+
+	for _, class := range cfg.SampleStatusClasses {
+		if status/100 == class {
+			return true
+		}
+	}
+
+	return n%uint64(cfg.SampleRate) == 0
+}
+
+// WithFields attaches extra fields to ctx that will be included in the
+// access-log record for this request. Call it from any before-middleware
+// (auth, session, ...) that runs ahead of the access-log middleware.
+func WithFields(ctx *atreugo.RequestCtx, fields map[string]interface{}) {
+	existing, _ := ctx.UserValue(accessLogFieldsCtxKey).(map[string]interface{})
+	if existing == nil {
+		existing = make(map[string]interface{}, len(fields))
+	}
+
+	for k, v := range fields {
+		existing[k] = v
+	}
+
+	ctx.SetUserValue(accessLogFieldsCtxKey, existing)
+}
+
+// RequestID returns the request id for ctx, reading it from the
+// X-Request-Id request header, or generating a new one if it's absent and
+// storing it as both the request and the response header, so later calls
+// for the same request return the same id.
+func RequestID(ctx *atreugo.RequestCtx) string {
+	id := string(ctx.Request.Header.Peek(requestIDHeader))
+	if id == "" {
+		id = newSessionID()
+		ctx.Request.Header.Set(requestIDHeader, id)
+		ctx.Response.Header.Set(requestIDHeader, id)
+	}
+
+	return id
+}
+
+type accessLogFieldsCtxKeyType struct{}
+
+var accessLogFieldsCtxKey = accessLogFieldsCtxKeyType{}
+
+// NewAccessLog returns an atreugo.Middleware meant to be registered with
+// Router.UseAfter, which emits one structured record per request capturing
+// method, path, status, bytes, latency, remote IP, user-agent, request id
+// and referrer, plus any fields attached with WithFields.
+//
+// Because it must observe the final response status and body size, it has
+// to run after the view, so it needs to be paired with an early
+// UseBefore-registered middleware (or RequestID call) if RequestID is to be
+// read by the view itself.
+func NewAccessLog(cfg AccessLogConfig) atreugo.Middleware {
+	cfg.setDefaults()
+
+	var sampleCounter uint64
+
+	return func(ctx *atreugo.RequestCtx) (int, error) {
+		start, _ := ctx.UserValue(accessLogStartCtxKey).(time.Time)
+		if start.IsZero() {
+			start = time.Now()
+		}
+
+		status := ctx.Response.StatusCode()
+
+		n := atomic.AddUint64(&sampleCounter, 1)
+		if !cfg.shouldSample(n, status) {
+			return 0, nil
+		}
+
+		fields, _ := ctx.UserValue(accessLogFieldsCtxKey).(map[string]interface{})
+
+		rec := AccessLogRecord{
+			Time:      start,
+			Method:    string(ctx.Method()),
+			Path:      string(ctx.Path()),
+			Status:    status,
+			Bytes:     len(ctx.Response.Body()),
+			Latency:   time.Since(start),
+			RemoteIP:  ctx.RemoteIP().String(),
+			UserAgent: string(ctx.UserAgent()),
+			Referrer:  string(ctx.Referer()),
+			RequestID: RequestID(ctx),
+			Fields:    fields,
+		}
+
+		line := cfg.Formatter(rec)
+
+		if cfg.SlowThreshold > 0 && rec.Latency >= cfg.SlowThreshold {
+			cfg.Logger.Warning(line)
+		} else {
+			cfg.Logger.Info(line)
+		}
+
+		return 0, nil
+	}
+}
+
+type accessLogStartCtxKeyType struct{}
+
+var accessLogStartCtxKey = accessLogStartCtxKeyType{}
+
+// NewAccessLogStart returns an atreugo.Middleware meant to be registered
+// with Router.UseBefore, which records the time the request started so
+// NewAccessLog can compute an accurate latency.
+func NewAccessLogStart() atreugo.Middleware {
+	return func(ctx *atreugo.RequestCtx) (int, error) {
+		ctx.SetUserValue(accessLogStartCtxKey, time.Now())
+		return 0, nil
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+
+	atreugo "github.com/savsgio/atreugo/v8"
+	"github.com/valyala/fasthttp"
+)
+
+func TestNewAccessLog(t *testing.T) {
+	var lastLine string
+
+	cfg := AccessLogConfig{
+		Formatter: func(rec AccessLogRecord) string {
+			lastLine = rec.Method + " " + rec.Path
+			return lastLine
+		},
+	}
+
+	viewFn := func(ctx *atreugo.RequestCtx) error {
+		WithFields(ctx, map[string]interface{}{"user": "gopher"})
+		return ctx.TextResponse("ok")
+	}
+
+	s := atreugo.New(&atreugo.Config{LogLevel: "error"})
+	s.UseBefore(NewAccessLogStart())
+	s.UseAfter(NewAccessLog(cfg))
+	s.Path("GET", "/hello", viewFn)
+
+	ln := serve(s)
+	defer ln.Close()
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.Header.SetMethod("GET")
+	req.SetRequestURI("/hello")
+
+	resp, err := doRequest(ln, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer fasthttp.ReleaseResponse(resp)
+
+	if !strings.Contains(lastLine, "GET /hello") {
+		t.Errorf("access log line = %q, want it to contain %q", lastLine, "GET /hello")
+	}
+}
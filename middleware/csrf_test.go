@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"testing"
+
+	atreugo "github.com/savsgio/atreugo/v8"
+	"github.com/valyala/fasthttp"
+)
+
+func TestNewCSRF(t *testing.T) {
+	viewFn := func(ctx *atreugo.RequestCtx) error {
+		return ctx.TextResponse("ok")
+	}
+
+	_, ln := newTestServer(viewFn, NewCSRF(CSRFConfig{}))
+	defer ln.Close()
+
+	getReq := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(getReq)
+	getReq.Header.SetMethod("GET")
+	getReq.SetRequestURI("/")
+
+	resp, err := doRequest(ln, getReq)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer fasthttp.ReleaseResponse(resp)
+
+	cookie := resp.Header.PeekCookie(defaultCSRFCookieName)
+	if len(cookie) == 0 {
+		t.Fatalf("expected a csrf cookie to be set on a safe request")
+	}
+
+	var c fasthttp.Cookie
+	if err := c.ParseBytes(cookie); err != nil {
+		t.Fatalf("unexpected error parsing cookie: %s", err)
+	}
+
+	token := string(c.Value())
+
+	postReq := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(postReq)
+	postReq.Header.SetMethod("POST")
+	postReq.SetRequestURI("/")
+	postReq.Header.SetCookie(defaultCSRFCookieName, token)
+	postReq.Header.Set(defaultCSRFHeaderName, token)
+
+	resp2, err := doRequest(ln, postReq)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer fasthttp.ReleaseResponse(resp2)
+
+	if resp2.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", resp2.StatusCode(), fasthttp.StatusOK)
+	}
+
+	postReq2 := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(postReq2)
+	postReq2.Header.SetMethod("POST")
+	postReq2.SetRequestURI("/")
+
+	resp3, err := doRequest(ln, postReq2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer fasthttp.ReleaseResponse(resp3)
+
+	if resp3.StatusCode() != fasthttp.StatusForbidden {
+		t.Errorf("status code = %d, want %d", resp3.StatusCode(), fasthttp.StatusForbidden)
+	}
+}
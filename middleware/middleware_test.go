@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bufio"
+
+	atreugo "github.com/savsgio/atreugo/v8"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func newTestServer(viewFn atreugo.View, mws ...atreugo.Middleware) (*atreugo.Atreugo, *fasthttputil.InmemoryListener) {
+	s := atreugo.New(&atreugo.Config{LogLevel: "error"})
+	s.UseBefore(mws...)
+	s.Path("GET", "/", viewFn)
+	s.Path("POST", "/", viewFn)
+
+	return s, serve(s)
+}
+
+func serve(s *atreugo.Atreugo) *fasthttputil.InmemoryListener {
+	ln := fasthttputil.NewInmemoryListener()
+
+	go s.Serve(ln) //nolint:errcheck
+
+	return ln
+}
+
+func doRequest(ln *fasthttputil.InmemoryListener, req *fasthttp.Request) (*fasthttp.Response, error) {
+	c, err := ln.Dial()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	if _, err := req.WriteTo(c); err != nil {
+		return nil, err
+	}
+
+	resp := fasthttp.AcquireResponse()
+	br := bufio.NewReader(c)
+
+	if err := resp.Read(br); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,255 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	atreugo "github.com/savsgio/atreugo/v8"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	defaultSessionCookieName = "_session"
+	defaultSessionMaxAge     = 24 * time.Hour
+	secretboxNonceSize       = 24
+)
+
+// ErrSessionInvalid is returned when the session cookie can't be decrypted
+// with any of the configured keys, e.g. because it was tampered with or all
+// the keys that signed it have been rotated out.
+var ErrSessionInvalid = errors.New("atreugo/middleware: invalid session cookie")
+
+type sessionCtxKeyType struct{}
+
+var sessionCtxKey = sessionCtxKeyType{}
+
+// Store persists session data out-of-band, keyed by session id. The cookie
+// itself only carries the id, authenticated-encrypted so it can't be forged
+// or read by the client.
+type Store interface {
+	// Get loads the data saved for id. ok is false if there's no such
+	// session or it has expired.
+	Get(id string) (data []byte, ok bool, err error)
+	// Save persists data for id, expiring it after ttl.
+	Save(id string, data []byte, ttl time.Duration) error
+	// Delete removes the session identified by id.
+	Delete(id string) error
+}
+
+// MemoryStore is an in-memory Store, suitable for development and tests.
+// A Redis-backed (or other persistent) Store only needs to implement the
+// same three methods.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memEntry
+}
+
+type memEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memEntry)}
+}
+
+// Get implements Store.
+func (m *MemoryStore) Get(id string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[id]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false, nil
+	}
+
+	return e.data, true, nil
+}
+
+// Save implements Store.
+func (m *MemoryStore) Save(id string, data []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[id] = memEntry{data: data, expires: time.Now().Add(ttl)}
+
+	return nil
+}
+
+// Delete implements Store.
+func (m *MemoryStore) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, id)
+
+	return nil
+}
+
+// SessionConfig configures NewSessionMiddleware.
+type SessionConfig struct {
+	// CookieName is the cookie holding the encrypted session id. Defaults
+	// to "_session".
+	CookieName string
+	// MaxAge is how long a session stays valid. Defaults to 24h.
+	MaxAge time.Duration
+
+	// Keys encrypt/authenticate the cookie payload with NaCl secretbox.
+	// Keys[0] is used to seal new cookies; every key is tried in order when
+	// opening one, so for rotation a new key is put first and the old one
+	// kept after it until no live cookie was sealed with it.
+	Keys []*[32]byte
+
+	// Store persists session values. Defaults to a MemoryStore.
+	Store Store
+
+	CookieSecure   bool
+	CookieSameSite fasthttp.CookieSameSite
+}
+
+func (cfg *SessionConfig) setDefaults() {
+	if cfg.CookieName == "" {
+		cfg.CookieName = defaultSessionCookieName
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultSessionMaxAge
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+}
+
+// Session is a per-request bag of values, loaded from and persisted to a
+// Store by the session middleware.
+type Session struct {
+	ID     string
+	Values map[string]interface{}
+
+	cfg   *SessionConfig
+	isNew bool
+}
+
+// NewSessionMiddleware returns an atreugo.Middleware that loads the session
+// referenced by the request's cookie (creating an empty one if absent or
+// invalid) and attaches it to ctx, retrievable with Get. Call Save to
+// persist changes and (re)issue the cookie.
+func NewSessionMiddleware(cfg SessionConfig) atreugo.Middleware {
+	cfg.setDefaults()
+
+	return func(ctx *atreugo.RequestCtx) (int, error) {
+		sess := loadSession(ctx, &cfg)
+		ctx.SetUserValue(sessionCtxKey, sess)
+
+		return 0, nil
+	}
+}
+
+func loadSession(ctx *atreugo.RequestCtx, cfg *SessionConfig) *Session {
+	cookie := ctx.Request.Header.Cookie(cfg.CookieName)
+
+	if len(cookie) > 0 {
+		if id, err := openSessionID(string(cookie), cfg.Keys); err == nil {
+			if data, ok, err := cfg.Store.Get(id); err == nil && ok {
+				values := map[string]interface{}{}
+				if json.Unmarshal(data, &values) == nil {
+					return &Session{ID: id, Values: values, cfg: cfg}
+				}
+			}
+		}
+	}
+
+	return &Session{ID: newSessionID(), Values: map[string]interface{}{}, cfg: cfg, isNew: true}
+}
+
+// Get returns the Session attached to ctx by the session middleware, or an
+// empty detached session if the middleware wasn't registered.
+func Get(ctx *atreugo.RequestCtx) *Session {
+	if sess, ok := ctx.UserValue(sessionCtxKey).(*Session); ok {
+		return sess
+	}
+
+	return &Session{Values: map[string]interface{}{}}
+}
+
+// Save persists the session's values to its Store and (re)issues the
+// session cookie.
+func (s *Session) Save(ctx *atreugo.RequestCtx) error {
+	if s.cfg == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(s.Values)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cfg.Store.Save(s.ID, data, s.cfg.MaxAge); err != nil {
+		return err
+	}
+
+	token, err := sealSessionID(s.ID, s.cfg.Keys)
+	if err != nil {
+		return err
+	}
+
+	c := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(c)
+
+	c.SetKey(s.cfg.CookieName)
+	c.SetValue(token)
+	c.SetPath("/")
+	c.SetHTTPOnly(true)
+	c.SetSecure(s.cfg.CookieSecure)
+	c.SetSameSite(s.cfg.CookieSameSite)
+	c.SetMaxAge(int(s.cfg.MaxAge.Seconds()))
+
+	ctx.Response.Header.SetCookie(c)
+
+	return nil
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func sealSessionID(id string, keys []*[32]byte) (string, error) {
+	if len(keys) == 0 {
+		return "", errors.New("atreugo/middleware: session middleware requires at least one key")
+	}
+
+	var nonce [secretboxNonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+
+	sealed := secretbox.Seal(nonce[:], []byte(id), &nonce, keys[0])
+
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func openSessionID(token string, keys []*[32]byte) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) < secretboxNonceSize {
+		return "", ErrSessionInvalid
+	}
+
+	var nonce [secretboxNonceSize]byte
+	copy(nonce[:], raw[:secretboxNonceSize])
+
+	for _, key := range keys {
+		if id, ok := secretbox.Open(nil, raw[secretboxNonceSize:], &nonce, key); ok {
+			return string(id), nil
+		}
+	}
+
+	return "", ErrSessionInvalid
+}
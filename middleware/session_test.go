@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"testing"
+
+	atreugo "github.com/savsgio/atreugo/v8"
+	"github.com/valyala/fasthttp"
+)
+
+func TestSessionMiddleware(t *testing.T) {
+	var key [32]byte
+	copy(key[:], []byte("01234567890123456789012345678901"))
+
+	cfg := SessionConfig{Keys: []*[32]byte{&key}}
+
+	viewFn := func(ctx *atreugo.RequestCtx) error {
+		sess := Get(ctx)
+
+		visits, _ := sess.Values["visits"].(float64)
+		sess.Values["visits"] = visits + 1
+
+		if err := sess.Save(ctx); err != nil {
+			return err
+		}
+
+		return ctx.TextResponse("ok")
+	}
+
+	_, ln := newTestServer(viewFn, NewSessionMiddleware(cfg))
+	defer ln.Close()
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.Header.SetMethod("POST")
+	req.SetRequestURI("/")
+
+	resp, err := doRequest(ln, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer fasthttp.ReleaseResponse(resp)
+
+	cookie := resp.Header.PeekCookie(defaultSessionCookieName)
+	if len(cookie) == 0 {
+		t.Fatalf("expected a session cookie to be set")
+	}
+
+	var c fasthttp.Cookie
+	if err := c.ParseBytes(cookie); err != nil {
+		t.Fatalf("unexpected error parsing cookie: %s", err)
+	}
+
+	req2 := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req2)
+	req2.Header.SetMethod("POST")
+	req2.SetRequestURI("/")
+	req2.Header.SetCookie(defaultSessionCookieName, string(c.Value()))
+
+	resp2, err := doRequest(ln, req2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer fasthttp.ReleaseResponse(resp2)
+
+	if resp2.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("status code = %d, want %d", resp2.StatusCode(), fasthttp.StatusOK)
+	}
+}
@@ -0,0 +1,19 @@
+package atreugo
+
+// Middleware is the function signature used by before/after filters.
+//
+// It returns the status code to respond with and an error if something went
+// wrong executing it. A nil error means that the following middleware/view
+// in the chain must continue its execution.
+type Middleware func(ctx *RequestCtx) (int, error)
+
+func execMiddlewares(ctx *RequestCtx, fns []Middleware) (int, error) {
+	for _, fn := range fns {
+		statusCode, err := fn(ctx)
+		if err != nil {
+			return statusCode, err
+		}
+	}
+
+	return 0, nil
+}
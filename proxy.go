@@ -0,0 +1,270 @@
+package atreugo
+
+import (
+	"crypto/tls"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ProxyOptions configures a reverse-proxy view registered with
+// Router.ReverseProxy.
+type ProxyOptions struct {
+	// Timeout bounds how long a single upstream request may take. Defaults
+	// to no timeout.
+	Timeout time.Duration
+
+	// MaxRetries is the number of additional attempts made against other
+	// backends when a request to one fails. Defaults to 0 (no retries).
+	MaxRetries int
+
+	// StripPath is removed from the beginning of the incoming request path
+	// before forwarding it upstream.
+	StripPath string
+
+	// RequestHeaders are added/overwritten on the outgoing request before
+	// it's forwarded upstream.
+	RequestHeaders map[string]string
+	// RemoveRequestHeaders are stripped from the outgoing request before
+	// it's forwarded upstream.
+	RemoveRequestHeaders []string
+
+	// ResponseHeaders are added/overwritten on the response before it's
+	// written back to the client.
+	ResponseHeaders map[string]string
+	// RemoveResponseHeaders are stripped from the response before it's
+	// written back to the client.
+	RemoveResponseHeaders []string
+
+	// ModifyResponse, if set, is called with the upstream response before
+	// it's written back to the client, mirroring
+	// httputil.ReverseProxy.ModifyResponse. Returning an error aborts the
+	// response with a StatusBadGateway.
+	ModifyResponse func(ctx *RequestCtx, resp *fasthttp.Response) error
+
+	// TLSConfig is used when connecting to backends over TLS.
+	TLSConfig *tls.Config
+
+	// HealthCheck, if set, is run periodically against every backend to
+	// decide whether it should keep receiving traffic. A non-nil error
+	// marks it unhealthy until a later run succeeds again.
+	HealthCheck func(backend string) error
+	// HealthCheckInterval is how often HealthCheck runs. Defaults to 10s.
+	HealthCheckInterval time.Duration
+}
+
+// ReverseProxy registers a view at url that load-balances matched requests
+// across backends using a fasthttp.LBClient, converting atreugo's
+// RequestCtx to/from fasthttp.Request/Response so that trailers and
+// chunked bodies are preserved, and existing before/after middlewares
+// (auth, rate-limiting, ...) still apply.
+func (r *Router) ReverseProxy(httpMethod, url string, backends []string, opts ProxyOptions) {
+	r.ReverseProxyWithFilters(httpMethod, url, backends, opts, emptyFilters)
+}
+
+// ReverseProxyWithFilters registers a reverse-proxy view like ReverseProxy,
+// with filters that will execute before and after.
+func (r *Router) ReverseProxyWithFilters(httpMethod, url string, backends []string,
+	opts ProxyOptions, filters Filters) {
+	lb, hostClients := newLBClient(backends, opts)
+
+	if opts.HealthCheck != nil {
+		interval := opts.HealthCheckInterval
+		if interval <= 0 {
+			interval = 10 * time.Second
+		}
+
+		stop := make(chan struct{})
+		for _, hc := range hostClients {
+			go hc.pollHealthCheck(opts.HealthCheck, interval, stop)
+		}
+
+		r.closers.add(func() { close(stop) })
+	}
+
+	viewFn := func(ctx *RequestCtx) error {
+		return proxyRequest(ctx, lb, hostClients, opts)
+	}
+
+	r.PathWithFilters(httpMethod, url, viewFn, filters)
+}
+
+// healthAwareClient wraps a fasthttp.HostClient, tracking opts.HealthCheck
+// results so it can report itself as overloaded (PendingRequests returning
+// math.MaxInt32) while unhealthy. fasthttp.LBClient's own HealthCheck hook
+// has no way to identify which backend a request went to, so both
+// LBClient's selection (via the BalancingClient interface) and our own
+// doWithRetries fallback read the same health state through this type
+// instead.
+type healthAwareClient struct {
+	*fasthttp.HostClient
+
+	healthy int32 // atomic bool, 1 = healthy
+}
+
+func newHealthAwareClient(addr string, opts ProxyOptions) *healthAwareClient {
+	return &healthAwareClient{
+		HostClient: &fasthttp.HostClient{
+			Addr:      addr,
+			TLSConfig: opts.TLSConfig,
+		},
+		healthy: 1,
+	}
+}
+
+func (c *healthAwareClient) PendingRequests() int {
+	if atomic.LoadInt32(&c.healthy) == 0 {
+		return math.MaxInt32
+	}
+
+	return c.HostClient.PendingRequests()
+}
+
+// pollHealthCheck runs checkFn against c's backend on every tick, updating
+// c's health state, until stop is closed.
+func (c *healthAwareClient) pollHealthCheck(checkFn func(backend string) error, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			healthy := int32(0)
+			if checkFn(c.Addr) == nil {
+				healthy = 1
+			}
+
+			atomic.StoreInt32(&c.healthy, healthy)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// newLBClient builds a fasthttp.LBClient whose Clients are healthAwareClient
+// wrappers, so a backend opts.HealthCheck marks unhealthy is deprioritized by
+// LBClient's own least-busy selection as well as by doWithRetries.
+func newLBClient(backends []string, opts ProxyOptions) (*fasthttp.LBClient, []*healthAwareClient) {
+	lb := &fasthttp.LBClient{}
+	hostClients := make([]*healthAwareClient, 0, len(backends))
+
+	for _, addr := range backends {
+		hc := newHealthAwareClient(addr, opts)
+
+		lb.Clients = append(lb.Clients, hc)
+		hostClients = append(hostClients, hc)
+	}
+
+	return lb, hostClients
+}
+
+func proxyRequest(ctx *RequestCtx, lb *fasthttp.LBClient, hostClients []*healthAwareClient, opts ProxyOptions) error {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	ctx.Request.CopyTo(req)
+
+	if opts.StripPath != "" {
+		path := string(req.URI().Path())
+		req.URI().SetPath(strings.TrimPrefix(path, opts.StripPath))
+	}
+
+	for k, v := range opts.RequestHeaders {
+		req.Header.Set(k, v)
+	}
+
+	for _, h := range opts.RemoveRequestHeaders {
+		req.Header.Del(h)
+	}
+
+	var err error
+	if opts.MaxRetries > 0 {
+		err = doWithRetries(hostClients, req, resp, opts)
+	} else if opts.Timeout > 0 {
+		err = lb.DoTimeout(req, resp, opts.Timeout)
+	} else {
+		err = lb.Do(req, resp)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if opts.ModifyResponse != nil {
+		if err := opts.ModifyResponse(ctx, resp); err != nil {
+			ctx.Error(err.Error(), fasthttp.StatusBadGateway)
+			return nil
+		}
+	}
+
+	for k, v := range opts.ResponseHeaders {
+		resp.Header.Set(k, v)
+	}
+
+	for _, h := range opts.RemoveResponseHeaders {
+		resp.Header.Del(h)
+	}
+
+	resp.CopyTo(&ctx.Response)
+
+	return nil
+}
+
+// doWithRetries retries a failed attempt against a different backend,
+// picking among hostClients by least pending requests (mirroring
+// fasthttp.LBClient's own selection, and so sharing its health awareness)
+// while excluding hosts that already failed this request, and resetting
+// resp before every attempt so a partial write from a failed attempt can't
+// leak into the next one.
+func doWithRetries(hostClients []*healthAwareClient, req *fasthttp.Request,
+	resp *fasthttp.Response, opts ProxyOptions) error {
+	excluded := make(map[string]bool, len(hostClients))
+
+	var err error
+
+	attempts := opts.MaxRetries + 1
+	for i := 0; i < attempts; i++ {
+		hc := leastBusyHostClient(hostClients, excluded)
+		if hc == nil {
+			break
+		}
+
+		resp.Reset()
+
+		if opts.Timeout > 0 {
+			err = hc.DoTimeout(req, resp, opts.Timeout)
+		} else {
+			err = hc.Do(req, resp)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		excluded[hc.Addr] = true
+	}
+
+	return err
+}
+
+func leastBusyHostClient(hostClients []*healthAwareClient, excluded map[string]bool) *healthAwareClient {
+	var best *healthAwareClient
+
+	for _, hc := range hostClients {
+		if excluded[hc.Addr] {
+			continue
+		}
+
+		if best == nil || hc.PendingRequests() < best.PendingRequests() {
+			best = hc
+		}
+	}
+
+	return best
+}
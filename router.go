@@ -1,8 +1,10 @@
 package atreugo
 
 import (
+	"context"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	fastrouter "github.com/fasthttp/router"
@@ -13,10 +15,33 @@ import (
 
 var emptyFilters = Filters{}
 
+// Router wraps a fasthttp/router.Router, registering views as fasthttp handlers
+type Router struct {
+	log    *logger.Logger
+	router *fastrouter.Router
+	server *fasthttp.Server
+
+	beforeMiddlewares []Middleware
+	afterMiddlewares  []Middleware
+
+	// scheduler is the default admission-control policy for routes
+	// registered on this router, set from Config.Scheduler. It may be
+	// overridden per-route through Filters.Scheduler.
+	scheduler Scheduler
+
+	// closers collects shutdown hooks registered by router features that
+	// spawn background goroutines (e.g. ReverseProxy's health checks), so
+	// they can be stopped when the server shuts down. Shared with groups
+	// created through NewGroupPath, so registering on a group is cleaned up
+	// too.
+	closers *closerRegistry
+}
+
 func newRouter(log *logger.Logger) *Router {
 	r := new(Router)
 	r.log = log
 	r.router = fastrouter.New()
+	r.closers = new(closerRegistry)
 
 	return r
 }
@@ -26,10 +51,37 @@ func (r *Router) NewGroupPath(path string) *Router {
 	g := new(Router)
 	g.log = r.log
 	g.router = r.router.Group(path)
+	g.server = r.server
+	g.scheduler = r.scheduler
+	g.closers = r.closers
 
 	return g
 }
 
+// closerRegistry collects stop functions for background goroutines spawned
+// by router features, so they can all be stopped together on shutdown.
+type closerRegistry struct {
+	mu      sync.Mutex
+	closers []func()
+}
+
+func (c *closerRegistry) add(fn func()) {
+	c.mu.Lock()
+	c.closers = append(c.closers, fn)
+	c.mu.Unlock()
+}
+
+func (c *closerRegistry) closeAll() {
+	c.mu.Lock()
+	closers := c.closers
+	c.closers = nil
+	c.mu.Unlock()
+
+	for _, fn := range closers {
+		fn()
+	}
+}
+
 func (r *Router) addRoute(httpMethod, url string, handler fasthttp.RequestHandler) {
 	if httpMethod != strings.ToUpper(httpMethod) {
 		panic("The http method '" + httpMethod + "' must be in uppercase")
@@ -42,6 +94,11 @@ func (r *Router) handler(viewFn View, filters Filters) fasthttp.RequestHandler {
 	before := append(r.beforeMiddlewares, filters.Before...)
 	after := append(filters.After, r.afterMiddlewares...)
 
+	scheduler := filters.Scheduler
+	if scheduler == nil {
+		scheduler = r.scheduler
+	}
+
 	return func(ctx *fasthttp.RequestCtx) {
 		actx := acquireRequestCtx(ctx)
 
@@ -49,6 +106,21 @@ func (r *Router) handler(viewFn View, filters Filters) fasthttp.RequestHandler {
 			r.log.Debugf("%s %s", actx.Method(), actx.URI())
 		}
 
+		if scheduler != nil {
+			if err := scheduler.Acquire(context.Background(), 0); err != nil {
+				statusCode := fasthttp.StatusBadGateway
+				if err == ErrSchedulerQueueFull {
+					statusCode = fasthttp.StatusServiceUnavailable
+				}
+
+				actx.Error(err.Error(), statusCode)
+				releaseRequestCtx(actx)
+
+				return
+			}
+			defer scheduler.Release()
+		}
+
 		var err error
 		var statusCode int
 
@@ -98,6 +170,18 @@ func (r *Router) PathWithFilters(httpMethod, url string, viewFn View, filters Fi
 	r.addRoute(httpMethod, url, r.handler(viewFn, filters))
 }
 
+// PathWithScheduler registers a new view with the given path and method,
+// attaching scheduler as an admission-control policy for this route only,
+// overriding the router's default Config.Scheduler.
+//
+// The scheduler's slot is acquired before the before-middlewares run and
+// released after the after-middlewares finish. If the queue is full it
+// responds with StatusServiceUnavailable, and if the wait for a slot times
+// out it responds with StatusBadGateway.
+func (r *Router) PathWithScheduler(httpMethod, url string, viewFn View, scheduler Scheduler) {
+	r.PathWithFilters(httpMethod, url, viewFn, Filters{Scheduler: scheduler})
+}
+
 // TimeoutPath registers a new view with the given path and method,
 // which returns StatusRequestTimeout error with the given msg to the client
 // if view didn't return during the given duration.
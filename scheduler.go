@@ -0,0 +1,243 @@
+package atreugo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrSchedulerQueueFull is returned by Scheduler.Acquire when the queue is
+// already at its maximum size.
+var ErrSchedulerQueueFull = errors.New("atreugo: scheduler queue is full")
+
+// ErrSchedulerTimeout is returned by Scheduler.Acquire when the caller waited
+// longer than the given timeout for a free slot.
+var ErrSchedulerTimeout = errors.New("atreugo: scheduler wait timeout")
+
+// SchedulerStats is a point-in-time snapshot of a Scheduler's counters,
+// meant to be wired into a metrics system.
+type SchedulerStats struct {
+	InFlight int64
+	Queued   int64
+	Rejected int64
+}
+
+// Scheduler is a pluggable admission-control policy for incoming requests,
+// similar in spirit to Kubernetes' MaxInFlightLimit and Skipper's fifo/lifo
+// filters. It bounds the number of requests that may run concurrently,
+// queueing the excess up to a limit and rejecting or failing the rest.
+type Scheduler interface {
+	// Acquire blocks until a slot is available, the context is cancelled or
+	// timeout elapses, whichever happens first.
+	Acquire(ctx context.Context, timeout time.Duration) error
+	// Release frees a slot previously obtained with Acquire.
+	Release()
+	// Stats returns a snapshot of the scheduler's counters.
+	Stats() SchedulerStats
+}
+
+type fifoScheduler struct {
+	tokens chan struct{}
+	queue  chan struct{}
+	wait   time.Duration
+
+	mu       sync.Mutex
+	inFlight int64
+	rejected int64
+}
+
+// NewFIFOScheduler returns a Scheduler that admits up to maxConcurrency
+// requests at once, queueing up to maxQueueSize more in first-in-first-out
+// order. wait is used as the default Acquire timeout when callers pass 0.
+func NewFIFOScheduler(maxConcurrency, maxQueueSize int, wait time.Duration) Scheduler {
+	return &fifoScheduler{
+		tokens: make(chan struct{}, maxConcurrency),
+		queue:  make(chan struct{}, maxQueueSize),
+		wait:   wait,
+	}
+}
+
+func (s *fifoScheduler) Acquire(ctx context.Context, timeout time.Duration) error {
+	select {
+	case s.tokens <- struct{}{}:
+		s.mu.Lock()
+		s.inFlight++
+		s.mu.Unlock()
+
+		return nil
+	default:
+	}
+
+	select {
+	case s.queue <- struct{}{}:
+	default:
+		s.mu.Lock()
+		s.rejected++
+		s.mu.Unlock()
+
+		return ErrSchedulerQueueFull
+	}
+	defer func() { <-s.queue }()
+
+	if timeout <= 0 {
+		timeout = s.wait
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case s.tokens <- struct{}{}:
+		s.mu.Lock()
+		s.inFlight++
+		s.mu.Unlock()
+
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		s.mu.Lock()
+		s.rejected++
+		s.mu.Unlock()
+
+		return ErrSchedulerTimeout
+	}
+}
+
+func (s *fifoScheduler) Release() {
+	<-s.tokens
+
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+}
+
+func (s *fifoScheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return SchedulerStats{
+		InFlight: s.inFlight,
+		Queued:   int64(len(s.queue)),
+		Rejected: s.rejected,
+	}
+}
+
+type lifoScheduler struct {
+	maxConcurrency int
+	maxQueueSize   int
+	wait           time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	waiters  []chan struct{}
+	rejected int64
+}
+
+// NewLIFOScheduler returns a Scheduler that admits up to maxConcurrency
+// requests at once, queueing up to maxQueueSize more and waking the
+// most-recently-queued one first (last-in-first-out), which favors freshly
+// arrived requests over stale ones under sustained overload. wait is used
+// as the default Acquire timeout when callers pass 0.
+func NewLIFOScheduler(maxConcurrency, maxQueueSize int, wait time.Duration) Scheduler {
+	return &lifoScheduler{
+		maxConcurrency: maxConcurrency,
+		maxQueueSize:   maxQueueSize,
+		wait:           wait,
+	}
+}
+
+func (s *lifoScheduler) Acquire(ctx context.Context, timeout time.Duration) error {
+	s.mu.Lock()
+
+	if s.inFlight < s.maxConcurrency {
+		s.inFlight++
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	if len(s.waiters) >= s.maxQueueSize {
+		s.rejected++
+		s.mu.Unlock()
+
+		return ErrSchedulerQueueFull
+	}
+
+	ch := make(chan struct{})
+	s.waiters = append(s.waiters, ch)
+	s.mu.Unlock()
+
+	if timeout <= 0 {
+		timeout = s.wait
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		if s.dropWaiter(ch) {
+			return ctx.Err()
+		}
+		// Woken up concurrently with the context being cancelled, slot is
+		// ours; returning ctx.Err() here would make the caller skip
+		// Release and leak it.
+		return nil
+	case <-timer.C:
+		if s.dropWaiter(ch) {
+			s.mu.Lock()
+			s.rejected++
+			s.mu.Unlock()
+
+			return ErrSchedulerTimeout
+		}
+		// Woken up concurrently with the timer firing, slot is ours.
+		return nil
+	}
+}
+
+// dropWaiter removes ch from the waiters stack if it's still there,
+// reporting whether the removal happened (false means it was already woken).
+func (s *lifoScheduler) dropWaiter(ch chan struct{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, w := range s.waiters {
+		if w == ch {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *lifoScheduler) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n := len(s.waiters); n > 0 {
+		ch := s.waiters[n-1]
+		s.waiters = s.waiters[:n-1]
+		close(ch)
+
+		return
+	}
+
+	s.inFlight--
+}
+
+func (s *lifoScheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return SchedulerStats{
+		InFlight: int64(s.inFlight),
+		Queued:   int64(len(s.waiters)),
+		Rejected: s.rejected,
+	}
+}
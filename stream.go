@@ -0,0 +1,162 @@
+package atreugo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// defaultMaxFrameSize bounds a single record read by a streaming route when
+// StreamOptions.MaxFrameSize is left unset.
+const defaultMaxFrameSize = 64 * 1024
+
+// lengthPrefixSize is the width, in bytes, of the big-endian length prefix
+// used by StreamFramingLengthPrefixed.
+const lengthPrefixSize = 4
+
+// ErrStreamFrameTooLarge is returned when a record read from a streamed
+// request body exceeds StreamOptions.MaxFrameSize.
+var ErrStreamFrameTooLarge = errors.New("atreugo: stream frame exceeds MaxFrameSize")
+
+// StreamFraming selects how Router.StreamPath splits a request body stream
+// into records.
+type StreamFraming int
+
+const (
+	// StreamFramingLineDelimited splits the body on '\n', the default.
+	StreamFramingLineDelimited StreamFraming = iota
+	// StreamFramingLengthPrefixed reads a 4-byte big-endian length prefix
+	// followed by that many bytes as a single record, repeated until EOF.
+	StreamFramingLengthPrefixed
+)
+
+// StreamRecordFunc is called once per record parsed from a streamed request
+// body, registered with Router.StreamPath.
+type StreamRecordFunc func(ctx *RequestCtx, record []byte) error
+
+// StreamOptions configures a route registered with Router.StreamPath.
+type StreamOptions struct {
+	// Framing selects how the body stream is split into records. Defaults
+	// to StreamFramingLineDelimited.
+	Framing StreamFraming
+
+	// MaxFrameSize bounds the size of a single record read from the body
+	// stream, so that a pathological payload can't grow memory unbounded.
+	// Defaults to 64KB.
+	MaxFrameSize int
+
+	// Scheduler, if set, bounds concurrent streaming requests the same way
+	// Filters.Scheduler does for regular routes.
+	Scheduler Scheduler
+}
+
+// StreamPath registers a new view with the given path and method that reads
+// the request body as a stream of records (line-delimited or
+// length-prefixed, see StreamOptions.Framing) instead of buffering it
+// whole, keeping memory bounded for large payloads (e.g. OpenMetrics-style
+// scrapes with millions of series). It enables Config.StreamRequestBody on
+// the underlying fasthttp.Server.
+func (r *Router) StreamPath(httpMethod, url string, recordFn StreamRecordFunc, opts StreamOptions) {
+	r.StreamPathWithFilters(httpMethod, url, recordFn, emptyFilters, opts)
+}
+
+// StreamPathWithFilters registers a new streaming view like StreamPath,
+// with filters that will execute before and after.
+func (r *Router) StreamPathWithFilters(httpMethod, url string, recordFn StreamRecordFunc,
+	filters Filters, opts StreamOptions) {
+	if r.server != nil {
+		r.server.StreamRequestBody = true
+	}
+
+	if filters.Scheduler == nil {
+		filters.Scheduler = opts.Scheduler
+	}
+
+	maxFrameSize := opts.MaxFrameSize
+	if maxFrameSize <= 0 {
+		maxFrameSize = defaultMaxFrameSize
+	}
+
+	viewFn := func(ctx *RequestCtx) error {
+		switch opts.Framing {
+		case StreamFramingLengthPrefixed:
+			return consumeLengthPrefixedStream(ctx, recordFn, maxFrameSize)
+		default:
+			return consumeLineStream(ctx, recordFn, maxFrameSize)
+		}
+	}
+
+	r.PathWithFilters(httpMethod, url, viewFn, filters)
+}
+
+// consumeLineStream reads '\n'-delimited records from the request body
+// stream, invoking recordFn for each one without buffering the whole body.
+func consumeLineStream(ctx *RequestCtx, recordFn StreamRecordFunc, maxFrameSize int) error {
+	// One extra byte leaves room for the '\n' ending a maxFrameSize record.
+	reader := bufio.NewReaderSize(ctx.RequestBodyStream(), maxFrameSize+1)
+
+	for {
+		line, err := reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			return ErrStreamFrameTooLarge
+		}
+
+		if len(line) > 0 {
+			if recErr := recordFn(ctx, bytes.TrimRight(line, "\n")); recErr != nil {
+				return recErr
+			}
+		}
+
+		switch err {
+		case nil:
+			continue
+		case io.EOF:
+			return nil
+		case bufio.ErrBufferFull:
+			return ErrStreamFrameTooLarge
+		default:
+			return err
+		}
+	}
+}
+
+// consumeLengthPrefixedStream reads records framed as a 4-byte big-endian
+// length prefix followed by that many bytes, invoking recordFn for each one
+// without buffering the whole body.
+func consumeLengthPrefixedStream(ctx *RequestCtx, recordFn StreamRecordFunc, maxFrameSize int) error {
+	reader := bufio.NewReaderSize(ctx.RequestBodyStream(), maxFrameSize)
+
+	var lenBuf [lengthPrefixSize]byte
+	buf := make([]byte, maxFrameSize)
+
+	for {
+		if _, err := io.ReadFull(reader, lenBuf[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		size := binary.BigEndian.Uint32(lenBuf[:])
+		if size > uint32(maxFrameSize) {
+			return ErrStreamFrameTooLarge
+		}
+
+		record := buf[:size]
+
+		if _, err := io.ReadFull(reader, record); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return err
+		}
+
+		if err := recordFn(ctx, record); err != nil {
+			return err
+		}
+	}
+}
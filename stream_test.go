@@ -0,0 +1,216 @@
+package atreugo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestRouter_StreamPath(t *testing.T) {
+	var records int
+	var maxRecordLen int
+
+	s := New(&Config{LogLevel: "error"})
+	s.StreamPath("POST", "/ingest", func(ctx *RequestCtx, record []byte) error {
+		records++
+		if len(record) > maxRecordLen {
+			maxRecordLen = len(record)
+		}
+
+		return nil
+	}, StreamOptions{MaxFrameSize: 1024})
+
+	if !s.server.StreamRequestBody {
+		t.Fatalf("StreamPath must enable StreamRequestBody on the underlying server")
+	}
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	serverCh := make(chan error, 1)
+	go func() {
+		serverCh <- s.Serve(ln)
+	}()
+
+	const lineCount = 200000
+	line := bytes.Repeat([]byte("a"), 100)
+
+	var body bytes.Buffer
+	for i := 0; i < lineCount; i++ {
+		body.Write(line)
+		body.WriteByte('\n')
+	}
+
+	runtime.GC()
+
+	var memBefore runtime.MemStats
+	runtime.ReadMemStats(&memBefore)
+
+	clientCh := make(chan struct{})
+	go func() {
+		defer close(clientCh)
+
+		c, err := ln.Dial()
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			return
+		}
+		defer c.Close()
+
+		req := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(req)
+
+		req.Header.SetMethod("POST")
+		req.SetRequestURI("/ingest")
+		req.SetBody(body.Bytes())
+
+		if _, err = req.WriteTo(c); err != nil {
+			t.Errorf("unexpected error: %s", err)
+			return
+		}
+
+		br := bufio.NewReader(c)
+		var resp fasthttp.Response
+		if err = resp.Read(br); err != nil {
+			t.Errorf("unexpected error: %s", err)
+			return
+		}
+
+		if resp.StatusCode() != fasthttp.StatusOK {
+			t.Errorf("unexpected status code: %d", resp.StatusCode())
+		}
+	}()
+
+	select {
+	case <-clientCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	select {
+	case <-serverCh:
+	case <-time.After(time.Second):
+		t.Fatalf("timeout")
+	}
+
+	runtime.GC()
+
+	var memAfter runtime.MemStats
+	runtime.ReadMemStats(&memAfter)
+
+	if records != lineCount {
+		t.Errorf("records = %d, want %d", records, lineCount)
+	}
+
+	if maxRecordLen != len(line) {
+		t.Errorf("maxRecordLen = %d, want %d", maxRecordLen, len(line))
+	}
+
+	// Streaming must keep memory use well below the body size; a regression
+	// that buffers the whole body before parsing it would allocate at least
+	// bodyLen bytes on top of the client's own copy.
+	bodyLen := uint64(body.Len())
+	allocated := memAfter.TotalAlloc - memBefore.TotalAlloc
+
+	if allocated > bodyLen/2 {
+		t.Errorf("processing a %d byte body allocated %d bytes, want well under %d; "+
+			"looks like the body is being buffered instead of streamed", bodyLen, allocated, bodyLen/2)
+	}
+}
+
+func TestRouter_StreamPath_LengthPrefixed(t *testing.T) {
+	var got [][]byte
+
+	s := New(&Config{LogLevel: "error"})
+	s.StreamPath("POST", "/ingest", func(ctx *RequestCtx, record []byte) error {
+		got = append(got, append([]byte(nil), record...))
+		return nil
+	}, StreamOptions{Framing: StreamFramingLengthPrefixed, MaxFrameSize: 1024})
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	serverCh := make(chan error, 1)
+	go func() {
+		serverCh <- s.Serve(ln)
+	}()
+
+	records := [][]byte{[]byte("hello"), []byte(""), []byte("world!")}
+
+	var body bytes.Buffer
+	for _, record := range records {
+		var lenBuf [4]byte
+		binary.BigEndian.PutUint32(lenBuf[:], uint32(len(record)))
+		body.Write(lenBuf[:])
+		body.Write(record)
+	}
+
+	clientCh := make(chan struct{})
+	go func() {
+		defer close(clientCh)
+
+		c, err := ln.Dial()
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			return
+		}
+		defer c.Close()
+
+		req := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(req)
+
+		req.Header.SetMethod("POST")
+		req.SetRequestURI("/ingest")
+		req.SetBody(body.Bytes())
+
+		if _, err = req.WriteTo(c); err != nil {
+			t.Errorf("unexpected error: %s", err)
+			return
+		}
+
+		br := bufio.NewReader(c)
+		var resp fasthttp.Response
+		if err = resp.Read(br); err != nil {
+			t.Errorf("unexpected error: %s", err)
+			return
+		}
+
+		if resp.StatusCode() != fasthttp.StatusOK {
+			t.Errorf("unexpected status code: %d", resp.StatusCode())
+		}
+	}()
+
+	select {
+	case <-clientCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	select {
+	case <-serverCh:
+	case <-time.After(time.Second):
+		t.Fatalf("timeout")
+	}
+
+	if len(got) != len(records) {
+		t.Fatalf("got %d records, want %d", len(got), len(records))
+	}
+
+	for i, record := range records {
+		if !bytes.Equal(got[i], record) {
+			t.Errorf("record %d = %q, want %q", i, got[i], record)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package atreugo
+
+import "github.com/valyala/fasthttp"
+
+// View is the function signature used to register a route handler
+type View func(ctx *RequestCtx) error
+
+func viewToHandler(viewFn View) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		actx := acquireRequestCtx(ctx)
+
+		if err := viewFn(actx); err != nil {
+			actx.Error(err.Error(), fasthttp.StatusInternalServerError)
+		}
+
+		releaseRequestCtx(actx)
+	}
+}